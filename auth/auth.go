@@ -0,0 +1,159 @@
+// Package auth implements signed upload-token authorization for the tus
+// upload handler. Tokens are short-lived HS256 JWTs scoped to a single
+// object (identified by its SHA-256 digest) plus an arbitrary caller
+// supplied parameter string, and are verified by Middleware before a
+// request ever reaches the tus handler.
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Header names that Middleware sets once a token has been verified, so
+// that a PreUploadCreateCallback can copy the claims into the upload's
+// metadata the same way it already copies the "filename" header.
+const (
+	HeaderSub   = "X-Upload-Sub"
+	HeaderParam = "X-Upload-Param"
+
+	cookieName = "upload-auth-token"
+	queryParam = "auth_token"
+)
+
+// Claims are the custom claims carried by an upload token.
+type Claims struct {
+	Param string `json:"param,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// GetUploadToken issues an HS256 token authorizing an upload of the
+// object identified by sha256, carrying the opaque param, and expiring
+// after ttl.
+func GetUploadToken(secret []byte, sha256, param string, ttl time.Duration) (string, error) {
+	claims := Claims{
+		Param: param,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   sha256,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+}
+
+// Middleware verifies the upload token carried by the request and, on
+// success, copies its claims into the HeaderSub/HeaderParam request
+// headers before calling next. The token is looked up, in order, as a
+// Bearer Authorization header, an "upload-auth-token" cookie, or an
+// "auth_token" query parameter, so that browser clients which cannot set
+// headers (e.g. a plain `<a href>` download/upload link) can still
+// authenticate. On failure it writes a 401 JSON error and does not call
+// next.
+func Middleware(secret []byte, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		token, err := extractToken(req)
+		if err != nil {
+			writeUnauthorized(w, err)
+			return
+		}
+
+		claims, err := verify(secret, token)
+		if err != nil {
+			writeUnauthorized(w, err)
+			return
+		}
+
+		req.Header.Set(HeaderSub, claims.Subject)
+		req.Header.Set(HeaderParam, claims.Param)
+		next.ServeHTTP(w, req)
+	})
+}
+
+func extractToken(req *http.Request) (string, error) {
+	if header := req.Header.Get("Authorization"); header != "" {
+		parts := strings.SplitN(header, " ", 2)
+		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+			return "", errors.New("malformed Authorization header")
+		}
+		return parts[1], nil
+	}
+	if cookie, err := req.Cookie(cookieName); err == nil && cookie.Value != "" {
+		return cookie.Value, nil
+	}
+	if token := req.URL.Query().Get(queryParam); token != "" {
+		return token, nil
+	}
+	return "", errors.New("no upload auth token supplied")
+}
+
+func verify(secret []byte, token string) (*Claims, error) {
+	claims := &Claims{}
+	parsed, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return secret, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid upload auth token: %w", err)
+	}
+	if !parsed.Valid {
+		return nil, errors.New("invalid upload auth token")
+	}
+	return claims, nil
+}
+
+func writeUnauthorized(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+func writeForbidden(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// OwnerFunc resolves the sub claim that was bound to id at creation time
+// (as recorded by a PreUploadCreateCallback copying HeaderSub into the
+// upload's metadata). It returns an error if id does not exist.
+type OwnerFunc func(ctx context.Context, id string) (string, error)
+
+// RequireOwner wraps next so that requests addressing an existing upload
+// are rejected unless the verified token's subject (HeaderSub, set by
+// Middleware) matches that upload's owning sub, as resolved by owner.
+// RequireOwner must run after Middleware. The tus upload-creation
+// request (POST directly to basePath, with no ID yet) has nothing to
+// check ownership against and is passed through unchanged; the
+// PreUploadCreateCallback is what binds the token's subject to the new
+// upload in the first place.
+func RequireOwner(basePath string, owner OwnerFunc, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		id := strings.Trim(strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, basePath), "/info"), "/")
+		if id == "" {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		sub, err := owner(req.Context(), id)
+		if err != nil {
+			http.NotFound(w, req)
+			return
+		}
+
+		if sub != req.Header.Get(HeaderSub) {
+			writeForbidden(w, errors.New("token is not scoped to this upload"))
+			return
+		}
+
+		next.ServeHTTP(w, req)
+	})
+}
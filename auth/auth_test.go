@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var secret = []byte("test-secret")
+
+func TestMiddlewareAcceptsBearerToken(t *testing.T) {
+	token, err := GetUploadToken(secret, "deadbeef", "caller-data", time.Minute)
+	if err != nil {
+		t.Fatalf("GetUploadToken() returned error: %s", err)
+	}
+
+	var gotSub, gotParam string
+	handler := Middleware(secret, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSub = r.Header.Get(HeaderSub)
+		gotParam = r.Header.Get(HeaderParam)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/files/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if gotSub != "deadbeef" {
+		t.Errorf("sub = %q, want %q", gotSub, "deadbeef")
+	}
+	if gotParam != "caller-data" {
+		t.Errorf("param = %q, want %q", gotParam, "caller-data")
+	}
+}
+
+func TestMiddlewareAcceptsCookieToken(t *testing.T) {
+	token, err := GetUploadToken(secret, "deadbeef", "", time.Minute)
+	if err != nil {
+		t.Fatalf("GetUploadToken() returned error: %s", err)
+	}
+
+	handler := Middleware(secret, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/files/deadbeef/info", nil)
+	req.AddCookie(&http.Cookie{Name: "upload-auth-token", Value: token})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestMiddlewareAcceptsQueryToken(t *testing.T) {
+	token, err := GetUploadToken(secret, "deadbeef", "", time.Minute)
+	if err != nil {
+		t.Fatalf("GetUploadToken() returned error: %s", err)
+	}
+
+	handler := Middleware(secret, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/files/deadbeef/info?auth_token="+token, nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestMiddlewarePrefersHeaderOverCookieAndQuery(t *testing.T) {
+	headerToken, err := GetUploadToken(secret, "header", "", time.Minute)
+	if err != nil {
+		t.Fatalf("GetUploadToken() returned error: %s", err)
+	}
+	cookieToken, err := GetUploadToken(secret, "cookie", "", time.Minute)
+	if err != nil {
+		t.Fatalf("GetUploadToken() returned error: %s", err)
+	}
+
+	var gotSub string
+	handler := Middleware(secret, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSub = r.Header.Get(HeaderSub)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/files/header/info?auth_token=should-be-ignored", nil)
+	req.Header.Set("Authorization", "Bearer "+headerToken)
+	req.AddCookie(&http.Cookie{Name: "upload-auth-token", Value: cookieToken})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if gotSub != "header" {
+		t.Errorf("sub = %q, want %q (the Authorization header should win)", gotSub, "header")
+	}
+}
+
+func TestMiddlewareRejectsMissingToken(t *testing.T) {
+	handler := Middleware(secret, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("next handler should not be called without a token")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/files/", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestMiddlewareRejectsExpiredToken(t *testing.T) {
+	token, err := GetUploadToken(secret, "deadbeef", "", -time.Minute)
+	if err != nil {
+		t.Fatalf("GetUploadToken() returned error: %s", err)
+	}
+
+	handler := Middleware(secret, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("next handler should not be called with an expired token")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/files/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestRequireOwnerAllowsMatchingSub(t *testing.T) {
+	owner := func(ctx context.Context, id string) (string, error) {
+		return "alice", nil
+	}
+
+	called := false
+	handler := RequireOwner("/files/", owner, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/files/deadbeef/info", nil)
+	req.Header.Set(HeaderSub, "alice")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if !called {
+		t.Fatal("next handler was not called for a matching sub")
+	}
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestRequireOwnerRejectsMismatchedSub(t *testing.T) {
+	owner := func(ctx context.Context, id string) (string, error) {
+		return "alice", nil
+	}
+
+	handler := RequireOwner("/files/", owner, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("next handler should not be called for a mismatched sub")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/files/deadbeef/info", nil)
+	req.Header.Set(HeaderSub, "bob")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestRequireOwnerRejectsUnknownUpload(t *testing.T) {
+	owner := func(ctx context.Context, id string) (string, error) {
+		return "", errors.New("not found")
+	}
+
+	handler := RequireOwner("/files/", owner, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("next handler should not be called for an unknown upload")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/files/missing/info", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestRequireOwnerPassesThroughUploadCreation(t *testing.T) {
+	owner := func(ctx context.Context, id string) (string, error) {
+		t.Fatal("owner should not be consulted for an upload-creation request")
+		return "", nil
+	}
+
+	called := false
+	handler := RequireOwner("/files/", owner, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/files/", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if !called {
+		t.Fatal("next handler was not called for an upload-creation request")
+	}
+}
+
+func TestMiddlewareRejectsTokenSignedWithWrongSecret(t *testing.T) {
+	token, err := GetUploadToken([]byte("a-different-secret"), "deadbeef", "", time.Minute)
+	if err != nil {
+		t.Fatalf("GetUploadToken() returned error: %s", err)
+	}
+
+	handler := Middleware(secret, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("next handler should not be called with a token signed by a different secret")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/files/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,90 @@
+// Package cors wraps an http.Handler with CORS headers, since browser
+// clients served from another origin need permissive preflight handling
+// that the bundled tus handler does not provide out of the box.
+package cors
+
+import (
+	"flag"
+	"net/http"
+	"strings"
+)
+
+// Config describes which origins, headers, and methods are allowed.
+type Config struct {
+	AllowedOrigins []string
+	AllowedHeaders []string
+	AllowedMethods []string
+}
+
+// Flags registers comma-separated-list flags backing a Config onto fs
+// and returns it. Call Parse after flag.Parse to populate the slices.
+func Flags(fs *flag.FlagSet) *flagConfig {
+	fc := &flagConfig{}
+	fs.StringVar(&fc.origins, "cors-allowed-origins", "*", "comma-separated list of origins allowed to make tus requests")
+	fs.StringVar(&fc.headers, "cors-allowed-headers", "Authorization, Content-Type, Tus-Resumable, Upload-Length, Upload-Metadata, Upload-Offset", "comma-separated list of headers allowed in tus requests")
+	fs.StringVar(&fc.methods, "cors-allowed-methods", "GET, POST, HEAD, PATCH, DELETE, OPTIONS", "comma-separated list of methods allowed in tus requests")
+	return fc
+}
+
+// flagConfig holds the raw flag values until Parse splits them into a
+// Config.
+type flagConfig struct {
+	origins string
+	headers string
+	methods string
+}
+
+// Parse splits the flag values registered by Flags into a Config.
+func (fc *flagConfig) Parse() Config {
+	return Config{
+		AllowedOrigins: splitList(fc.origins),
+		AllowedHeaders: splitList(fc.headers),
+		AllowedMethods: splitList(fc.methods),
+	}
+}
+
+func splitList(s string) []string {
+	parts := strings.Split(s, ",")
+	out := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Wrap returns next wrapped with CORS headers driven by cfg. A request
+// whose Origin is not allowed gets no CORS headers, so the browser will
+// reject the response. OPTIONS requests are answered with 204 No Content
+// and never reach next.
+func Wrap(cfg Config, next http.Handler) http.Handler {
+	allowedHeaders := strings.Join(cfg.AllowedHeaders, ", ")
+	allowedMethods := strings.Join(cfg.AllowedMethods, ", ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		origin := req.Header.Get("Origin")
+		if origin != "" && isAllowedOrigin(cfg.AllowedOrigins, origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Headers", allowedHeaders)
+			w.Header().Set("Access-Control-Allow-Methods", allowedMethods)
+			w.Header().Set("Access-Control-Expose-Headers", "Location, Upload-Offset, Upload-Length")
+		}
+
+		if req.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, req)
+	})
+}
+
+func isAllowedOrigin(allowed []string, origin string) bool {
+	for _, a := range allowed {
+		if a == "*" || a == origin {
+			return true
+		}
+	}
+	return false
+}
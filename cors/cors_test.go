@@ -0,0 +1,82 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWrapAllowsConfiguredOrigin(t *testing.T) {
+	cfg := Config{
+		AllowedOrigins: []string{"https://example.com"},
+		AllowedHeaders: []string{"Upload-Offset"},
+		AllowedMethods: []string{"PATCH"},
+	}
+	handler := Wrap(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodPatch, "/files/abc", nil)
+	req.Header.Set("Origin", "https://example.com")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://example.com" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, "https://example.com")
+	}
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestWrapRejectsUnconfiguredOrigin(t *testing.T) {
+	cfg := Config{AllowedOrigins: []string{"https://example.com"}}
+	handler := Wrap(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodPatch, "/files/abc", nil)
+	req.Header.Set("Origin", "https://evil.example")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want empty", got)
+	}
+}
+
+func TestWrapWildcardAllowsAnyOrigin(t *testing.T) {
+	cfg := Config{AllowedOrigins: []string{"*"}}
+	handler := Wrap(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/files/abc", nil)
+	req.Header.Set("Origin", "https://anything.example")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://anything.example" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, "https://anything.example")
+	}
+}
+
+func TestWrapAnswersPreflightWithNoContent(t *testing.T) {
+	cfg := Config{AllowedOrigins: []string{"*"}}
+	called := false
+	handler := Wrap(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	req := httptest.NewRequest(http.MethodOptions, "/files/abc", nil)
+	req.Header.Set("Origin", "https://example.com")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if called {
+		t.Error("next handler should not be called for a preflight request")
+	}
+}
@@ -0,0 +1,108 @@
+// Package janitor periodically sweeps a filestore upload directory for
+// abandoned uploads: ones whose data file shows no progress past a
+// configurable idle TTL, so crashed clients don't leak disk forever.
+package janitor
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tusd "github.com/tus/tusd/pkg/handler"
+)
+
+// Config controls the sweep interval and idle TTL.
+type Config struct {
+	Interval time.Duration
+	IdleTTL  time.Duration
+}
+
+// Flags registers the flags backing a Config onto fs and returns it.
+func Flags(fs *flag.FlagSet) *Config {
+	cfg := &Config{}
+	fs.DurationVar(&cfg.Interval, "janitor-interval", 10*time.Minute, "how often to sweep for abandoned uploads")
+	fs.DurationVar(&cfg.IdleTTL, "janitor-idle-ttl", 24*time.Hour, "delete incomplete uploads whose data file hasn't been written to in this long")
+	return cfg
+}
+
+// Run sweeps dir every cfg.Interval, deleting the data and .info files of
+// incomplete uploads whose data file has not been written to in
+// cfg.IdleTTL. evict is called with the ID of every upload removed this
+// way, so a registry.Registry cache can be told to forget it too; it may
+// be nil. Run blocks, so it is typically run in its own goroutine.
+func Run(cfg Config, dir string, evict func(id string)) {
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		sweep(dir, cfg.IdleTTL, evict)
+	}
+}
+
+func sweep(dir string, idleTTL time.Duration, evict func(id string)) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		fmt.Printf("janitor: unable to read %s: %s\n", dir, err)
+		return
+	}
+
+	cutoff := time.Now().Add(-idleTTL)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".info") {
+			continue
+		}
+
+		id := strings.TrimSuffix(entry.Name(), ".info")
+		infoPath := filepath.Join(dir, entry.Name())
+		dataPath := filepath.Join(dir, id)
+
+		raw, err := os.ReadFile(infoPath)
+		if err != nil {
+			continue
+		}
+		var fileInfo tusd.FileInfo
+		if err := json.Unmarshal(raw, &fileInfo); err != nil {
+			continue
+		}
+		// The Offset stored in .info is the one recorded at creation (see
+		// below), so the data file's size is used as the current offset.
+		offset := fileInfo.Offset
+		if data, err := os.Stat(dataPath); err == nil {
+			offset = data.Size()
+		}
+		if offset >= fileInfo.Size {
+			// The upload is complete; leave it for whatever consumes
+			// finished uploads instead of sweeping it as abandoned.
+			continue
+		}
+
+		// The data file is written to on every WriteChunk, so its mtime
+		// reflects the upload's last activity. .info is only written once, at
+		// creation, so it's used as a fallback for uploads that were
+		// created but never received a single chunk.
+		activity, err := os.Stat(dataPath)
+		if err != nil {
+			activity, err = os.Stat(infoPath)
+			if err != nil {
+				continue
+			}
+		}
+		if activity.ModTime().After(cutoff) {
+			continue
+		}
+
+		if err := os.Remove(infoPath); err != nil {
+			fmt.Printf("janitor: unable to remove %s: %s\n", entry.Name(), err)
+		}
+		if err := os.Remove(dataPath); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("janitor: unable to remove %s: %s\n", id, err)
+		}
+		if evict != nil {
+			evict(id)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package janitor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tusd "github.com/tus/tusd/pkg/handler"
+)
+
+func writeUpload(t *testing.T, dir, id string, size, offset int64, dataAge, infoAge time.Duration) {
+	t.Helper()
+
+	raw, err := json.Marshal(tusd.FileInfo{ID: id, Size: size, Offset: offset})
+	if err != nil {
+		t.Fatalf("marshal FileInfo: %s", err)
+	}
+	infoPath := filepath.Join(dir, id+".info")
+	if err := os.WriteFile(infoPath, raw, 0o644); err != nil {
+		t.Fatalf("write .info: %s", err)
+	}
+	if err := os.Chtimes(infoPath, time.Now().Add(-infoAge), time.Now().Add(-infoAge)); err != nil {
+		t.Fatalf("chtimes .info: %s", err)
+	}
+
+	dataPath := filepath.Join(dir, id)
+	if err := os.WriteFile(dataPath, make([]byte, offset), 0o644); err != nil {
+		t.Fatalf("write data: %s", err)
+	}
+	if err := os.Chtimes(dataPath, time.Now().Add(-dataAge), time.Now().Add(-dataAge)); err != nil {
+		t.Fatalf("chtimes data: %s", err)
+	}
+}
+
+func exists(t *testing.T, path string) bool {
+	t.Helper()
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestSweepDeletesStaleIncompleteUpload(t *testing.T) {
+	dir := t.TempDir()
+	writeUpload(t, dir, "stale", 100, 10, 2*time.Hour, 2*time.Hour)
+
+	sweep(dir, time.Hour, nil)
+
+	if exists(t, filepath.Join(dir, "stale.info")) {
+		t.Error("expected stale upload's .info to be removed")
+	}
+	if exists(t, filepath.Join(dir, "stale")) {
+		t.Error("expected stale upload's data file to be removed")
+	}
+}
+
+func TestSweepKeepsRecentlyWrittenUpload(t *testing.T) {
+	dir := t.TempDir()
+	// The upload was created long ago but a chunk was written recently,
+	// so the data file mtime (not the .info mtime) should keep it alive.
+	writeUpload(t, dir, "active", 100, 10, time.Minute, 2*time.Hour)
+
+	sweep(dir, time.Hour, nil)
+
+	if !exists(t, filepath.Join(dir, "active.info")) {
+		t.Error("expected an upload with recent write activity to survive the sweep")
+	}
+}
+
+func TestSweepEvictsDeletedUpload(t *testing.T) {
+	dir := t.TempDir()
+	writeUpload(t, dir, "stale", 100, 10, 2*time.Hour, 2*time.Hour)
+
+	var evicted []string
+	sweep(dir, time.Hour, func(id string) {
+		evicted = append(evicted, id)
+	})
+
+	if len(evicted) != 1 || evicted[0] != "stale" {
+		t.Errorf("evicted = %v, want [\"stale\"]", evicted)
+	}
+}
+
+func TestSweepDoesNotEvictSurvivingUpload(t *testing.T) {
+	dir := t.TempDir()
+	writeUpload(t, dir, "active", 100, 10, time.Minute, 2*time.Hour)
+
+	evicted := false
+	sweep(dir, time.Hour, func(id string) {
+		evicted = true
+	})
+
+	if evicted {
+		t.Error("expected no eviction for an upload that survives the sweep")
+	}
+}
+
+func TestSweepKeepsCompletedUpload(t *testing.T) {
+	dir := t.TempDir()
+	writeUpload(t, dir, "done", 100, 100, 2*time.Hour, 2*time.Hour)
+
+	sweep(dir, time.Hour, nil)
+
+	if !exists(t, filepath.Join(dir, "done.info")) {
+		t.Error("expected a completed upload to survive the sweep regardless of age")
+	}
+}
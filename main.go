@@ -1,26 +1,62 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
-	"github.com/tus/tusd/pkg/filestore"
+	"github.com/setlog/tus-example/auth"
+	"github.com/setlog/tus-example/cors"
+	"github.com/setlog/tus-example/janitor"
+	"github.com/setlog/tus-example/metrics"
+	"github.com/setlog/tus-example/registry"
+	"github.com/setlog/tus-example/shutdown"
+	"github.com/setlog/tus-example/storage"
 	tusd "github.com/tus/tusd/pkg/handler"
 )
 
+// uploadAuthSecret signs and verifies upload tokens. In production this
+// must come from a secret store rather than the environment.
+var uploadAuthSecret = []byte(os.Getenv("UPLOAD_AUTH_SECRET"))
+
 func main() {
-	store := filestore.New("./uploads")
+	if len(uploadAuthSecret) == 0 {
+		panic(fmt.Errorf("UPLOAD_AUTH_SECRET must be set to a non-empty value"))
+	}
+
+	storageConfig := storage.Flags(flag.CommandLine)
+	corsFlags := cors.Flags(flag.CommandLine)
+	metricsConfig := metrics.Flags(flag.CommandLine)
+	shutdownConfig := shutdown.Flags(flag.CommandLine)
+	janitorConfig := janitor.Flags(flag.CommandLine)
+	flag.Parse()
 
-	composer := tusd.NewStoreComposer()
-	store.UseIn(composer)
+	corsConfig := corsFlags.Parse()
+
+	composer, err := storageConfig.Compose(context.Background())
+	if err != nil {
+		panic(fmt.Errorf("Unable to configure storage backend: %s", err))
+	}
 
 	handler, err := tusd.NewHandler(tusd.Config{
-		BasePath:              "/files/",
-		StoreComposer:         composer,
-		NotifyCompleteUploads: true,
+		BasePath:                "/files/",
+		StoreComposer:           composer,
+		MaxSize:                 storageConfig.MaxSize,
+		DisableDownload:         storageConfig.DisableDownload,
+		DisableTermination:      storageConfig.DisableTermination,
+		NotifyCompleteUploads:   true,
+		NotifyCreatedUploads:    true,
+		NotifyTerminatedUploads: true,
+		NotifyUploadProgress:    true,
 		PreUploadCreateCallback: func(hook tusd.HookEvent) error {
 			hook.Upload.MetaData["filename"] = hook.HTTPRequest.Header.Get("filename")
+			hook.Upload.MetaData["sub"] = hook.HTTPRequest.Header.Get(auth.HeaderSub)
+			hook.Upload.MetaData["param"] = hook.HTTPRequest.Header.Get(auth.HeaderParam)
 			return nil
 		},
 	})
@@ -28,33 +64,91 @@ func main() {
 		panic(fmt.Errorf("Unable to create handler: %s", err))
 	}
 
+	uploads := registry.New(composer.Core)
+
+	go func() {
+		for event := range handler.CreatedUploads {
+			uploads.Created(event.Upload)
+			metrics.Created(event.Upload)
+		}
+	}()
+
 	go func() {
-		for {
-			event := <-handler.CompleteUploads
+		for event := range handler.TerminatedUploads {
+			uploads.Terminated(event.Upload.ID)
+			metrics.Terminated(event.Upload)
+		}
+	}()
+
+	go func() {
+		for event := range handler.CompleteUploads {
+			uploads.Complete(event.Upload)
+			metrics.Complete(event.Upload)
 			fmt.Printf("Upload %s finished\n", event.Upload.ID)
 		}
 	}()
 
+	go metrics.WatchProgress(handler.UploadProgress)
+
+	if storageConfig.Backend == storage.BackendFilestore || storageConfig.Backend == "" {
+		go janitor.Run(*janitorConfig, storageConfig.FilestorePath, uploads.Terminated)
+	}
+
 	customHandler := http.StripPrefix("/files/", handler)
 	handler.Middleware(customHandler)
-	http.Handle("/files/", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		err := checkJWT(req.Header.Get("Authorization"))
-		if err != nil {
-			w.WriteHeader(http.StatusUnauthorized)
+	infoHandler := uploads.InfoHandler("/files/")
+	filesHandler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasSuffix(req.URL.Path, "/info") {
+			infoHandler.ServeHTTP(w, req)
+			return
 		}
 		customHandler.ServeHTTP(w, req)
-	}))
+	})
+	owner := func(ctx context.Context, id string) (string, error) {
+		info, err := uploads.Get(ctx, id)
+		if err != nil {
+			return "", err
+		}
+		return info.MetaData["sub"], nil
+	}
+	protectedFilesHandler := auth.Middleware(uploadAuthSecret, auth.RequireOwner("/files/", owner, filesHandler))
 
-	err = http.ListenAndServe(":8080", nil)
-	if err != nil {
-		panic(fmt.Errorf("Unable to listen: %s", err))
+	drainer := &shutdown.Drainer{}
+
+	mux := http.NewServeMux()
+	mux.Handle("/files/", drainer.Middleware(metrics.Middleware(cors.Wrap(corsConfig, protectedFilesHandler))))
+	mux.Handle("/healthz", drainer.HealthzHandler())
+	mux.Handle("/readyz", drainer.ReadyzHandler())
+	if metricsConfig.Expose {
+		mux.Handle(metricsConfig.Path, metrics.Handler())
 	}
-}
 
-func checkJWT(authorizationHeader string) error {
-	jwt := strings.TrimLeft(authorizationHeader, "Bearer ")
-	if jwt != "TrueJWT" {
-		return fmt.Errorf("Access denied")
+	server := &http.Server{Addr: ":8080", Handler: mux}
+
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+		<-sig
+
+		fmt.Println("Shutting down, draining in-flight uploads...")
+		drainer.Drain()
+
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownConfig.GracePeriod)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			fmt.Printf("Error during shutdown: %s\n", err)
+			return
+		}
+		drainer.Wait()
+	}()
+
+	err = server.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
+		panic(fmt.Errorf("Unable to listen: %s", err))
 	}
-	return nil
+	// ListenAndServe returns as soon as Shutdown starts; wait for the
+	// drain to finish before exiting.
+	<-shutdownDone
 }
@@ -0,0 +1,128 @@
+// Package metrics exposes Prometheus collectors for the tus handler:
+// open connections, bytes received, uploads created/finished/terminated,
+// and HTTP errors by status code.
+package metrics
+
+import (
+	"flag"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	tusd "github.com/tus/tusd/pkg/handler"
+)
+
+// Config controls whether and where metrics are exposed.
+type Config struct {
+	Expose bool
+	Path   string
+}
+
+// Flags registers the flags backing a Config onto fs and returns it.
+func Flags(fs *flag.FlagSet) *Config {
+	cfg := &Config{}
+	fs.BoolVar(&cfg.Expose, "expose-metrics", false, "expose a Prometheus metrics endpoint")
+	fs.StringVar(&cfg.Path, "metrics-path", "/metrics", "path to serve Prometheus metrics on")
+	return cfg
+}
+
+var (
+	openConnections = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "tusd_open_connections",
+		Help: "Number of open connections to the tus handler.",
+	})
+	bytesReceived = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "tusd_bytes_received_total",
+		Help: "Number of bytes received by the tus handler.",
+	})
+	uploadsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "tusd_uploads_created_total",
+		Help: "Number of uploads created.",
+	})
+	uploadsFinished = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "tusd_uploads_finished_total",
+		Help: "Number of uploads completed.",
+	})
+	uploadsTerminated = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "tusd_uploads_terminated_total",
+		Help: "Number of uploads terminated before completion.",
+	})
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "tusd_request_duration_seconds",
+		Help: "Latency of requests served by the tus handler.",
+	}, []string{"method"})
+	httpErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "tusd_http_errors_total",
+		Help: "Number of non-2xx/3xx responses from the tus handler, by status code.",
+	}, []string{"code"})
+)
+
+// Created records a newly created upload.
+func Created(tusd.FileInfo) {
+	uploadsCreated.Inc()
+}
+
+// Terminated records an upload cancelled before completion.
+func Terminated(tusd.FileInfo) {
+	uploadsTerminated.Inc()
+}
+
+// Complete records a finished upload.
+func Complete(tusd.FileInfo) {
+	uploadsFinished.Inc()
+}
+
+// lastOffset remembers the most recently observed offset per upload ID,
+// so WatchProgress can report the delta as bytes received rather than
+// double-counting the running total on every progress tick.
+var lastOffset sync.Map // upload ID -> int64
+
+// WatchProgress consumes handler.UploadProgress to update the
+// bytes-received counter. It blocks until progress is closed, so it is
+// typically run in its own goroutine.
+func WatchProgress(progress <-chan tusd.HookEvent) {
+	for event := range progress {
+		prev, _ := lastOffset.LoadOrStore(event.Upload.ID, int64(0))
+		if delta := event.Upload.Offset - prev.(int64); delta > 0 {
+			bytesReceived.Add(float64(delta))
+			lastOffset.Store(event.Upload.ID, event.Upload.Offset)
+		}
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Middleware wraps next to track open connections, observe request
+// latency, and count HTTP errors by status code.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		openConnections.Inc()
+		defer openConnections.Dec()
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, req)
+		requestDuration.WithLabelValues(req.Method).Observe(time.Since(start).Seconds())
+
+		if rec.status >= 400 {
+			httpErrors.WithLabelValues(strconv.Itoa(rec.status)).Inc()
+		}
+	})
+}
+
+// Handler returns the Prometheus scrape endpoint.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
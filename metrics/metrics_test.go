@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	tusd "github.com/tus/tusd/pkg/handler"
+)
+
+func TestMiddlewareCountsHTTPErrorsByCode(t *testing.T) {
+	before := testutil.ToFloat64(httpErrors.WithLabelValues("500"))
+
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPatch, "/files/abc", nil))
+
+	after := testutil.ToFloat64(httpErrors.WithLabelValues("500"))
+	if after != before+1 {
+		t.Errorf("tusd_http_errors_total{code=\"500\"} = %v, want %v", after, before+1)
+	}
+}
+
+func TestMiddlewareDoesNotCountSuccessAsError(t *testing.T) {
+	before := testutil.ToFloat64(httpErrors.WithLabelValues("200"))
+
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPatch, "/files/abc", nil))
+
+	after := testutil.ToFloat64(httpErrors.WithLabelValues("200"))
+	if after != before {
+		t.Errorf("tusd_http_errors_total{code=\"200\"} = %v, want unchanged at %v", after, before)
+	}
+}
+
+func TestCreatedCompleteTerminatedIncrementCounters(t *testing.T) {
+	beforeCreated := testutil.ToFloat64(uploadsCreated)
+	beforeFinished := testutil.ToFloat64(uploadsFinished)
+	beforeTerminated := testutil.ToFloat64(uploadsTerminated)
+
+	Created(tusd.FileInfo{ID: "abc"})
+	Complete(tusd.FileInfo{ID: "abc"})
+	Terminated(tusd.FileInfo{ID: "def"})
+
+	if got := testutil.ToFloat64(uploadsCreated); got != beforeCreated+1 {
+		t.Errorf("uploadsCreated = %v, want %v", got, beforeCreated+1)
+	}
+	if got := testutil.ToFloat64(uploadsFinished); got != beforeFinished+1 {
+		t.Errorf("uploadsFinished = %v, want %v", got, beforeFinished+1)
+	}
+	if got := testutil.ToFloat64(uploadsTerminated); got != beforeTerminated+1 {
+		t.Errorf("uploadsTerminated = %v, want %v", got, beforeTerminated+1)
+	}
+}
+
+func TestWatchProgressAccumulatesOffsetDeltaAsBytesReceived(t *testing.T) {
+	before := testutil.ToFloat64(bytesReceived)
+
+	progress := make(chan tusd.HookEvent, 2)
+	progress <- tusd.HookEvent{Upload: tusd.FileInfo{ID: "progress-test", Offset: 10}}
+	progress <- tusd.HookEvent{Upload: tusd.FileInfo{ID: "progress-test", Offset: 30}}
+	close(progress)
+
+	WatchProgress(progress)
+
+	after := testutil.ToFloat64(bytesReceived)
+	if after != before+30 {
+		t.Errorf("bytesReceived = %v, want %v (10 then +20 more)", after, before+30)
+	}
+}
@@ -0,0 +1,84 @@
+// Package registry keeps an in-memory view of upload FileInfo, keyed by
+// upload ID, so that HTTP handlers can serve cheap status lookups
+// without relying on tus HEAD semantics.
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	tusd "github.com/tus/tusd/pkg/handler"
+)
+
+// Registry tracks in-progress and completed uploads in memory. The zero
+// value is not usable; construct one with New.
+type Registry struct {
+	core    tusd.DataStore
+	uploads sync.Map // upload ID -> tusd.FileInfo
+}
+
+// New returns a Registry that falls back to core on a cache miss.
+func New(core tusd.DataStore) *Registry {
+	return &Registry{core: core}
+}
+
+// Created records a newly created upload. Callers are expected to invoke
+// this from whatever goroutine already consumes
+// handler.CreatedUploads, so the registry does not need its own reader
+// on that channel.
+func (r *Registry) Created(upload tusd.FileInfo) {
+	r.uploads.Store(upload.ID, upload)
+}
+
+// Complete records an upload that has finished.
+func (r *Registry) Complete(upload tusd.FileInfo) {
+	r.uploads.Store(upload.ID, upload)
+}
+
+// Terminated removes an upload that was cancelled before completion.
+func (r *Registry) Terminated(id string) {
+	r.uploads.Delete(id)
+}
+
+// Get returns the FileInfo for id, consulting the in-memory cache first
+// and falling back to the store on a miss.
+func (r *Registry) Get(ctx context.Context, id string) (tusd.FileInfo, error) {
+	if cached, ok := r.uploads.Load(id); ok {
+		return cached.(tusd.FileInfo), nil
+	}
+
+	upload, err := r.core.GetUpload(ctx, id)
+	if err != nil {
+		return tusd.FileInfo{}, err
+	}
+	return upload.GetInfo(ctx)
+}
+
+// InfoHandler serves GET /<basePath>/{id}/info, returning the upload's
+// FileInfo as JSON.
+func (r *Registry) InfoHandler(basePath string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		id := strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, basePath), "/info")
+		if id == "" {
+			http.NotFound(w, req)
+			return
+		}
+
+		info, err := r.Get(req.Context(), id)
+		if err != nil {
+			http.NotFound(w, req)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(info)
+	})
+}
@@ -0,0 +1,119 @@
+package registry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	tusd "github.com/tus/tusd/pkg/handler"
+)
+
+// fakeUpload implements tusd.Upload, returning a fixed FileInfo.
+type fakeUpload struct {
+	info tusd.FileInfo
+}
+
+func (u fakeUpload) WriteChunk(ctx context.Context, offset int64, src io.Reader) (int64, error) {
+	return 0, nil
+}
+func (u fakeUpload) GetInfo(ctx context.Context) (tusd.FileInfo, error) { return u.info, nil }
+func (u fakeUpload) GetReader(ctx context.Context) (io.Reader, error)   { return nil, nil }
+func (u fakeUpload) FinishUpload(ctx context.Context) error             { return nil }
+
+// fakeStore implements tusd.DataStore over a fixed set of uploads, so
+// tests can exercise the cache-miss fallback path without a real store.
+type fakeStore struct {
+	uploads map[string]tusd.FileInfo
+	gets    int
+}
+
+func (s *fakeStore) NewUpload(ctx context.Context, info tusd.FileInfo) (tusd.Upload, error) {
+	return fakeUpload{info: info}, nil
+}
+
+func (s *fakeStore) GetUpload(ctx context.Context, id string) (tusd.Upload, error) {
+	s.gets++
+	info, ok := s.uploads[id]
+	if !ok {
+		return nil, tusd.ErrNotFound
+	}
+	return fakeUpload{info: info}, nil
+}
+
+func TestGetServesFromCacheWithoutTouchingStore(t *testing.T) {
+	store := &fakeStore{uploads: map[string]tusd.FileInfo{}}
+	r := New(store)
+	r.Created(tusd.FileInfo{ID: "abc", Size: 100, Offset: 10})
+
+	info, err := r.Get(context.Background(), "abc")
+	if err != nil {
+		t.Fatalf("Get() returned error: %s", err)
+	}
+	if info.Offset != 10 {
+		t.Errorf("Offset = %d, want 10", info.Offset)
+	}
+	if store.gets != 0 {
+		t.Errorf("store.gets = %d, want 0 (should have served from cache)", store.gets)
+	}
+}
+
+func TestGetFallsBackToStoreOnCacheMiss(t *testing.T) {
+	store := &fakeStore{uploads: map[string]tusd.FileInfo{
+		"abc": {ID: "abc", Size: 100, Offset: 42},
+	}}
+	r := New(store)
+
+	info, err := r.Get(context.Background(), "abc")
+	if err != nil {
+		t.Fatalf("Get() returned error: %s", err)
+	}
+	if info.Offset != 42 {
+		t.Errorf("Offset = %d, want 42", info.Offset)
+	}
+	if store.gets != 1 {
+		t.Errorf("store.gets = %d, want 1 (should have fallen back to the store)", store.gets)
+	}
+}
+
+func TestTerminatedRemovesFromCache(t *testing.T) {
+	store := &fakeStore{uploads: map[string]tusd.FileInfo{}}
+	r := New(store)
+	r.Created(tusd.FileInfo{ID: "abc"})
+	r.Terminated("abc")
+
+	if _, err := r.Get(context.Background(), "abc"); err == nil {
+		t.Error("expected an error after the upload was terminated, got nil")
+	}
+}
+
+func TestInfoHandlerServesJSON(t *testing.T) {
+	store := &fakeStore{uploads: map[string]tusd.FileInfo{}}
+	r := New(store)
+	r.Created(tusd.FileInfo{ID: "abc", Size: 100, Offset: 10})
+
+	req := httptest.NewRequest(http.MethodGet, "/files/abc/info", nil)
+	rec := httptest.NewRecorder()
+	r.InfoHandler("/files/").ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+}
+
+func TestInfoHandlerNotFoundForUnknownUpload(t *testing.T) {
+	store := &fakeStore{uploads: map[string]tusd.FileInfo{}}
+	r := New(store)
+
+	req := httptest.NewRequest(http.MethodGet, "/files/missing/info", nil)
+	rec := httptest.NewRecorder()
+	r.InfoHandler("/files/").ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
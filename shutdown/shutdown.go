@@ -0,0 +1,86 @@
+// Package shutdown coordinates graceful draining of the HTTP server: it
+// refuses new upload creations once a shutdown has been requested while
+// letting in-flight PATCH continuations finish, and exposes /healthz and
+// /readyz probes reflecting that state.
+package shutdown
+
+import (
+	"flag"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Config controls how long the server waits for in-flight requests to
+// finish once a shutdown has been requested.
+type Config struct {
+	GracePeriod time.Duration
+}
+
+// Flags registers the flags backing a Config onto fs and returns it.
+func Flags(fs *flag.FlagSet) *Config {
+	cfg := &Config{}
+	fs.DurationVar(&cfg.GracePeriod, "shutdown-grace-period", 30*time.Second, "how long to wait for in-flight uploads to finish during shutdown")
+	return cfg
+}
+
+// Drainer tracks in-flight requests and whether the server is draining.
+// The zero value is ready to use.
+type Drainer struct {
+	wg       sync.WaitGroup
+	draining int32
+}
+
+// Draining reports whether Drain has been called.
+func (d *Drainer) Draining() bool {
+	return atomic.LoadInt32(&d.draining) == 1
+}
+
+// Drain marks the server as draining, so Middleware starts rejecting new
+// upload creations. It does not block; call Wait to wait for in-flight
+// requests to finish.
+func (d *Drainer) Drain() {
+	atomic.StoreInt32(&d.draining, 1)
+}
+
+// Wait blocks until every in-flight request tracked by Middleware has
+// finished.
+func (d *Drainer) Wait() {
+	d.wg.Wait()
+}
+
+// Middleware tracks in-flight requests in a WaitGroup so Wait can block
+// on them, and rejects new upload creations (POST requests) once the
+// server is draining; in-flight PATCH continuations are left alone.
+func (d *Drainer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if d.Draining() && req.Method == http.MethodPost {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		d.wg.Add(1)
+		defer d.wg.Done()
+		next.ServeHTTP(w, req)
+	})
+}
+
+// HealthzHandler always responds 200, signalling the process is alive.
+func (d *Drainer) HealthzHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// ReadyzHandler responds 200 unless the server is draining, in which
+// case it responds 503 so a load balancer stops routing new traffic.
+func (d *Drainer) ReadyzHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if d.Draining() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
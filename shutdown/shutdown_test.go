@@ -0,0 +1,102 @@
+package shutdown
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddlewareRejectsNewCreationsWhileDraining(t *testing.T) {
+	d := &Drainer{}
+	d.Drain()
+	handler := d.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/files/", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestMiddlewareAllowsPatchContinuationsWhileDraining(t *testing.T) {
+	d := &Drainer{}
+	d.Drain()
+	called := false
+	handler := d.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest(http.MethodPatch, "/files/abc", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if !called {
+		t.Error("expected an in-flight PATCH to still reach the handler while draining")
+	}
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+}
+
+func TestWaitBlocksUntilInFlightRequestsFinish(t *testing.T) {
+	d := &Drainer{}
+	release := make(chan struct{})
+	handler := d.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPatch, "/files/abc", nil))
+		close(done)
+	}()
+
+	waited := make(chan struct{})
+	go func() {
+		d.Wait()
+		close(waited)
+	}()
+
+	select {
+	case <-waited:
+		t.Fatal("Wait() returned before the in-flight request finished")
+	default:
+	}
+
+	close(release)
+	<-done
+	<-waited
+}
+
+func TestReadyzReflectsDrainState(t *testing.T) {
+	d := &Drainer{}
+
+	rec := httptest.NewRecorder()
+	d.ReadyzHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("before Drain: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	d.Drain()
+	rec = httptest.NewRecorder()
+	d.ReadyzHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("after Drain: status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestHealthzAlwaysOK(t *testing.T) {
+	d := &Drainer{}
+	d.Drain()
+
+	rec := httptest.NewRecorder()
+	d.HealthzHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
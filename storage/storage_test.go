@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"context"
+	"testing"
+)
+
+func TestComposeRequiresS3Bucket(t *testing.T) {
+	cfg := &Config{Backend: BackendS3}
+	if _, err := cfg.Compose(context.Background()); err == nil {
+		t.Error("expected an error when -s3-bucket is missing, got nil")
+	}
+}
+
+func TestComposeRequiresGCSBucket(t *testing.T) {
+	cfg := &Config{Backend: BackendGCS}
+	if _, err := cfg.Compose(context.Background()); err == nil {
+		t.Error("expected an error when -gcs-bucket is missing, got nil")
+	}
+}
+
+func TestComposeRejectsUnknownBackend(t *testing.T) {
+	cfg := &Config{Backend: "unknown"}
+	if _, err := cfg.Compose(context.Background()); err == nil {
+		t.Error("expected an error for an unknown backend, got nil")
+	}
+}
+
+func TestComposeDefaultsToFilestore(t *testing.T) {
+	cfg := &Config{FilestorePath: t.TempDir()}
+	composer, err := cfg.Compose(context.Background())
+	if err != nil {
+		t.Fatalf("Compose() returned error: %s", err)
+	}
+	if composer.Core == nil || !composer.UsesLocker {
+		t.Error("expected the default filestore backend to provide a core store and a locker")
+	}
+}